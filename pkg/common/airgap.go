@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// nodeTarballPath is where each preload pod expects the tarball to be
+// streamed in to, via copyTarballToPod, before it imports it.
+const nodeTarballPath = "/tarball/images.tar"
+
+// importedMarkerPath is touched by the preload container once the tarball
+// import succeeds; its readiness probe checks for it so the DaemonSet only
+// reports ready once every node has actually imported the images.
+const importedMarkerPath = "/tarball/.imported"
+
+// buildPreloadDaemonSet returns the DaemonSet spec that, once
+// copyTarballToPod has streamed the operator's local tarball into
+// nodeTarballPath on each pod's emptyDir, imports it into the node's
+// containerd store.
+func buildPreloadDaemonSet(namespace string) *appsv1.DaemonSet {
+	labels := map[string]string{"app": preloadDaemonSetName}
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      preloadDaemonSetName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					HostPID: true,
+					Containers: []corev1.Container{
+						{
+							Name:    "preload",
+							Image:   "registry.k8s.io/e2e-test-images/busybox:1.36.1-1",
+							Command: []string{"/bin/sh", "-c"},
+							Args: []string{
+								fmt.Sprintf("while [ ! -f %s ]; do sleep 1; done; ctr -n k8s.io images import %s && touch %s && sleep infinity",
+									nodeTarballPath, nodeTarballPath, importedMarkerPath),
+							},
+							SecurityContext: &corev1.SecurityContext{Privileged: boolPtr(true)},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{Command: []string{"cat", importedMarkerPath}},
+								},
+								PeriodSeconds: 5,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "tarball", MountPath: "/tarball"},
+								{Name: "containerd-sock", MountPath: "/run/containerd/containerd.sock"},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{Name: "tarball", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+						{
+							Name: "containerd-sock",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{Path: "/run/containerd/containerd.sock"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+// waitForDaemonSetReady polls until every desired replica of the named
+// DaemonSet is ready, or timeout elapses.
+func waitForDaemonSetReady(ctx context.Context, clientset kubernetes.Interface, namespace, name string, timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		ds, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return ds.Status.DesiredNumberScheduled > 0 && ds.Status.NumberReady == ds.Status.DesiredNumberScheduled, nil
+	})
+}
+
+// waitForPreloadPodsScheduled polls until at least one preload pod per
+// desired node has been scheduled (container created), so copyTarballToPod
+// has something to exec into.
+func waitForPreloadPodsScheduled(ctx context.Context, clientset kubernetes.Interface, namespace string, timeout time.Duration) ([]corev1.Pod, error) {
+	var pods []corev1.Pod
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		ds, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, preloadDaemonSetName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if ds.Status.DesiredNumberScheduled == 0 {
+			return false, nil
+		}
+
+		list, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(map[string]string{"app": preloadDaemonSetName}).String(),
+		})
+		if err != nil {
+			return false, err
+		}
+
+		ready := 0
+		for _, p := range list.Items {
+			for _, status := range p.Status.ContainerStatuses {
+				if status.State.Running != nil || status.State.Terminated != nil {
+					ready++
+					break
+				}
+			}
+		}
+		if ready < int(ds.Status.DesiredNumberScheduled) {
+			return false, nil
+		}
+
+		pods = list.Items
+		return true, nil
+	})
+	return pods, err
+}
+
+// copyTarballToPod streams the local file at tarballPath into
+// nodeTarballPath inside pod's preload container, mirroring the `cat`-based
+// approach copyFileFromPod uses to copy files out, but in reverse.
+func copyTarballToPod(ctx context.Context, restConfig *rest.Config, clientset kubernetes.Interface, namespace, pod, tarballPath string) error {
+	in, err := os.Open(tarballPath)
+	if err != nil {
+		return fmt.Errorf("opening tarball %s: %w", tarballPath, err)
+	}
+	defer in.Close()
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: "preload",
+			Command:   []string{"sh", "-c", "cat > " + nodeTarballPath + ".partial && mv " + nodeTarballPath + ".partial " + nodeTarballPath},
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("building exec request: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	if err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdin: in, Stderr: &stderr}); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+
+	return nil
+}
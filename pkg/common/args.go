@@ -17,14 +17,20 @@ limitations under the License.
 package common
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/dims/hydrophone/pkg/client"
 	"k8s.io/client-go/rest"
 	"log"
 	"os"
+	"strings"
 )
 
+// conformanceNamespace is the namespace hydrophone runs the conformance
+// pod (and its supporting preflight/preload workloads) in.
+const conformanceNamespace = "conformance"
+
 // ArgConfig stores the argument passed when running the program
 type ArgConfig struct {
 	// Focus set the E2E_FOCUS env var to run a specific test
@@ -36,10 +42,11 @@ type ArgConfig struct {
 
 	// ConformanceImage let's people use the conformance container image of their own choice
 	// Get the list of images from https://console.cloud.google.com/gcr/images/k8s-artifacts-prod/us/conformance
-	// default registry.k8s.io/conformance:v1.28.0
+	// left empty, ValidateArgs auto-selects a version-appropriate image based on the discovered server version
 	ConformanceImage string
 
 	// BusyboxImage lets folks use an appropriate busybox image from their own registry
+	// left empty, ValidateArgs fills it in from the active TestImageProvider
 	BusyboxImage string
 
 	// Kubeconfig is the path to the kubeconfig file
@@ -53,6 +60,98 @@ type ArgConfig struct {
 
 	// OutputDir is where the e2e.log and junit_01.xml is saved
 	OutputDir string
+
+	// Mode selects a curated Focus/Skip pair, mirroring Sonobuoy's plugin
+	// selectors, so users don't have to hand-craft focus regexes.
+	// e.g. - conformance, quick, non-disruptive-conformance, certified-conformance
+	Mode string
+
+	// Bundle, when set, tars up OutputDir into a result archive (tar.gz)
+	// once the run completes (see CreateResultBundle). The files are archived
+	// as Run leaves them, not rearranged into a Sonobuoy result archive's
+	// directory layout.
+	Bundle bool
+
+	// SkipKnownFailures, when set, appends the tests known to be broken on
+	// the discovered server version (see the knownFailures table) to
+	// E2E_SKIP, so users don't have to rediscover them on every new cluster.
+	SkipKnownFailures bool
+
+	// Config is the path to a YAML multi-run test plan (see PlanConfig).
+	// When set, InitArgs loads it, merges it with the CLI flags (see
+	// mergePlanConfig for which side takes precedence for each field), and
+	// populates Runs with the ordered list of runs to execute.
+	Config string
+
+	// Variables are passed through to the conformance pod as environment
+	// variables, as declared under the config file's `variables:` section.
+	Variables map[string]string
+
+	// Runs is the ordered list of test runs to execute, populated from the
+	// config file. When --config isn't used, this is left empty and the
+	// runner executes a single run built from the rest of ArgConfig.
+	Runs []TestRun
+
+	// Benchmark, when set, records per-test wall-clock durations parsed
+	// from the streaming ginkgo output and writes them to benchmark.json
+	// alongside junit_01.xml, so operators can track conformance
+	// regressions across releases.
+	Benchmark bool
+
+	// RegistryMirror, when set, selects the mirrorImageProvider so images
+	// are pulled from a private registry instead of registry.k8s.io.
+	RegistryMirror string
+
+	// RegistryAuthFile is an optional path to a Docker config JSON file
+	// (as written by `docker login`) used to authenticate against
+	// RegistryMirror.
+	RegistryAuthFile string
+
+	// AirGappedTarball, when set, selects the airGappedImageProvider:
+	// images are pre-loaded onto every node from this local tarball via a
+	// DaemonSet instead of being pulled from a registry.
+	AirGappedTarball string
+
+	// DryRun, when set, renders the effective E2E_FOCUS/E2E_SKIP and asks
+	// the conformance image to list the matching tests (--ginkgo.dryRun)
+	// instead of actually running them.
+	DryRun bool
+
+	// DryRunOutput is an optional file path the dry-run test list is also
+	// written to, in addition to stdout.
+	DryRunOutput string
+
+	// imagePullSecret is the ImagePullSecret name resolved from the active
+	// TestImageProvider (see RegistryAuthFile). Set by ValidateArgs, not a
+	// flag: Run attaches it to the conformance pod when non-empty.
+	imagePullSecret string
+}
+
+// modeSelector holds the Focus/Skip pair a --mode value resolves to.
+type modeSelector struct {
+	Focus string
+	Skip  string
+}
+
+// modes mirrors Sonobuoy's built-in plugin selectors. The bracketed tags are
+// regex metacharacters to E2E_FOCUS/E2E_SKIP, so they're escaped here to
+// match the literal tag instead of the character class they'd otherwise form.
+var modes = map[string]modeSelector{
+	"conformance": {
+		Focus: `\[Conformance\]`,
+	},
+	"quick": {
+		Focus: `\[Conformance\]`,
+		Skip:  `\[Serial\]|\[Disruptive\]|\[Slow\]`,
+	},
+	"non-disruptive-conformance": {
+		Focus: `\[Conformance\]`,
+		Skip:  `\[Disruptive\]`,
+	},
+	"certified-conformance": {
+		Focus: `\[Conformance\]`,
+		Skip:  `\[Disruptive\]|\[Flaky\]`,
+	},
 }
 
 func InitArgs() (*ArgConfig, error) {
@@ -65,18 +164,55 @@ func InitArgs() (*ArgConfig, error) {
 
 	flag.StringVar(&cfg.Focus, "focus", "", "focus runs a specific e2e test. e.g. - sig-auth. allows regular expressions.")
 	flag.StringVar(&cfg.Skip, "skip", "", "skip specific tests. allows regular expressions.")
-	flag.StringVar(&cfg.ConformanceImage, "conformance-image", containerImage,
-		"image let's you select your conformance container image of your choice.")
-	flag.StringVar(&cfg.BusyboxImage, "busybox-image", busyboxImage,
-		"image let's you select an alternate busybox container image.")
+	flag.StringVar(&cfg.ConformanceImage, "conformance-image", "",
+		"image let's you select your conformance container image of your choice. defaults to a version-appropriate registry.k8s.io/conformance image picked from the discovered server version.")
+	flag.StringVar(&cfg.BusyboxImage, "busybox-image", "",
+		"image let's you select an alternate busybox container image. defaults to the image from the active TestImageProvider.")
 	flag.StringVar(&cfg.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file.")
 	flag.IntVar(&cfg.Parallel, "parallel", 1, "number of parallel threads in test framework.")
 	flag.IntVar(&cfg.Verbosity, "verbosity", 4, "verbosity of test framework.")
 	flag.StringVar(&cfg.OutputDir, "output-dir", outputDir, "directory for logs.")
+	flag.StringVar(&cfg.Mode, "mode", "", "sonobuoy-compatible plugin mode. one of: conformance, quick, non-disruptive-conformance, certified-conformance. sets --focus/--skip for you unless overridden.")
+	flag.BoolVar(&cfg.Bundle, "bundle", false, "tar up the output directory into a sonobuoy-style result bundle (.tar.gz) once the run completes.")
+	flag.BoolVar(&cfg.SkipKnownFailures, "skip-known-failures", false, "append tests known to be broken on the discovered server version to --skip.")
+	flag.StringVar(&cfg.Config, "config", "", "path to a yaml multi-run test plan. merges with CLI flags, CLI flags take precedence.")
+	flag.BoolVar(&cfg.Benchmark, "benchmark", false, "record per-test wall-clock durations to benchmark.json alongside junit_01.xml and print the slowest tests in the summary.")
+	flag.StringVar(&cfg.RegistryMirror, "registry-mirror", "", "pull images from this private registry mirror instead of registry.k8s.io.")
+	flag.StringVar(&cfg.RegistryAuthFile, "registry-auth-file", "", "path to a docker config json file used to authenticate against --registry-mirror.")
+	flag.StringVar(&cfg.AirGappedTarball, "airgapped-tarball", "", "path to a local image tarball to pre-load onto every node via a daemonset, for clusters with no registry access at all.")
+	flag.BoolVar(&cfg.DryRun, "dry-run", false, "list the tests the effective focus/skip would select, without running them.")
+	flag.StringVar(&cfg.DryRunOutput, "dry-run-output", "", "optional file path to also write the --dry-run test list to.")
 
 	flag.Parse()
 
-	if cfg.Focus == "" {
+	if cfg.Config != "" {
+		plan, err := LoadPlanConfig(cfg.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		explicitFlags := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) {
+			explicitFlags[f.Name] = true
+		})
+
+		mergePlanConfig(&cfg, plan, explicitFlags)
+	}
+
+	if cfg.Mode != "" {
+		selector, ok := modes[cfg.Mode]
+		if !ok {
+			return nil, fmt.Errorf("unknown --mode %q, must be one of: conformance, quick, non-disruptive-conformance, certified-conformance", cfg.Mode)
+		}
+		if cfg.Focus == "" {
+			cfg.Focus = selector.Focus
+		}
+		if cfg.Skip == "" {
+			cfg.Skip = selector.Skip
+		}
+	}
+
+	if cfg.Focus == "" && len(cfg.Runs) == 0 {
 		return nil, fmt.Errorf("missing --focus argument (use '[Conformance]' to run all conformance tests)")
 	}
 
@@ -90,6 +226,57 @@ func ValidateArgs(err error, client *client.Client, config *rest.Config, cfg *Ar
 	}
 	log.Printf("API endpoint : %s", config.Host)
 	log.Printf("Server version : %#v", *serverVersion)
+	if cfg.Mode != "" {
+		log.Printf("Mode : '%s'", cfg.Mode)
+	}
+	if cfg.Benchmark {
+		log.Printf("Benchmark mode enabled, will write '%s' alongside junit_01.xml", BenchmarkName)
+	}
+
+	provider, err := NewTestImageProvider(context.Background(), client.ClientSet, conformanceNamespace, cfg)
+	if err != nil {
+		log.Fatal("Error selecting test image provider: ", err)
+	}
+	log.Printf("Using image provider : '%s'", provider.Name())
+	cfg.imagePullSecret = provider.PullSecret()
+
+	if cfg.ConformanceImage == "" {
+		cfg.ConformanceImage = provider.Conformance(serverVersion.GitVersion)
+		log.Printf("Auto-selected conformance image for server version '%s' : '%s'", serverVersion.GitVersion, cfg.ConformanceImage)
+	}
+	if cfg.BusyboxImage == "" {
+		cfg.BusyboxImage = provider.Busybox()
+	}
+
+	if cfg.AirGappedTarball != "" {
+		if err := EnsureAirGappedImages(context.Background(), config, client.ClientSet, conformanceNamespace, cfg.AirGappedTarball); err != nil {
+			log.Fatal("Error pre-loading air-gapped images: ", err)
+		}
+	} else if err := VerifyPullAccess(context.Background(), client.ClientSet, conformanceNamespace, []string{cfg.ConformanceImage, cfg.BusyboxImage}, cfg.imagePullSecret); err != nil {
+		log.Fatal("Error verifying image pull access: ", err)
+	}
+
+	if cfg.SkipKnownFailures {
+		parsedVersion, err := parseServerVersion(serverVersion.GitVersion)
+		if err != nil {
+			log.Fatalf("Error parsing server version [%s] : %v", serverVersion.GitVersion, err)
+		}
+		if skips := resolveKnownFailureSkips(parsedVersion); len(skips) > 0 {
+			log.Printf("Skipping known failures for server version '%s' : %v", serverVersion.GitVersion, skips)
+			extra := strings.Join(skips, "|")
+			withKnownFailures := func(skip string) string {
+				if skip == "" {
+					return extra
+				}
+				return skip + "|" + extra
+			}
+			cfg.Skip = withKnownFailures(cfg.Skip)
+			for i := range cfg.Runs {
+				cfg.Runs[i].Skip = withKnownFailures(cfg.Runs[i].Skip)
+			}
+		}
+	}
+
 	log.Printf("Running tests : '%s'", cfg.Focus)
 	if cfg.Skip != "" {
 		log.Printf("Skipping tests : '%s'", cfg.Skip)
@@ -104,4 +291,8 @@ func ValidateArgs(err error, client *client.Client, config *rest.Config, cfg *Ar
 			log.Fatalf("Error creating output directory [%s] : %v", cfg.OutputDir, err)
 		}
 	}
+
+	if err := Run(context.Background(), config, client.ClientSet, conformanceNamespace, cfg); err != nil {
+		log.Fatal("Error running conformance tests: ", err)
+	}
 }
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// BenchmarkName is the filename written alongside junit_01.xml when
+// ArgConfig.Benchmark is set.
+const BenchmarkName = "benchmark.json"
+
+// BenchmarkEntry records the timing of a single ginkgo test, parsed from
+// the streaming conformance pod output.
+type BenchmarkEntry struct {
+	Name       string `json:"name"`
+	DurationMS int64  `json:"duration_ms"`
+	Status     string `json:"status"`
+	Retries    int    `json:"retries"`
+}
+
+// ginkgoResultLine matches ginkgo's default reporter summary line, e.g.:
+//
+//	• [1.234 seconds] [sig-auth] ServiceAccounts should ... [It]
+//	• [SLOW TEST:12.345 seconds] [sig-apps] Deployment should ... [It]
+//	• [FAILED] [1.234 seconds] [sig-auth] ServiceAccounts should ... [It]
+var ginkgoResultLine = regexp.MustCompile(`^[•\-]\s*(\[FAILED\]|\[PASSED\])?\s*\[(?:SLOW TEST:)?([0-9.]+) seconds?\]\s*(.+)$`)
+
+// ParseGinkgoBenchmarks reads streaming ginkgo output from r and returns one
+// BenchmarkEntry per completed test. Retries are counted by how many times
+// the same test name is seen before a non-failing result is recorded.
+func ParseGinkgoBenchmarks(r io.Reader) []BenchmarkEntry {
+	retries := map[string]int{}
+	var entries []BenchmarkEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		matches := ginkgoResultLine.FindStringSubmatch(scanner.Text())
+		if matches == nil {
+			continue
+		}
+
+		status := "passed"
+		if matches[1] == "[FAILED]" {
+			status = "failed"
+		}
+
+		name := matches[3]
+		seconds := parseSeconds(matches[2])
+
+		entry := BenchmarkEntry{
+			Name:       name,
+			DurationMS: int64(seconds * 1000),
+			Status:     status,
+			Retries:    retries[name],
+		}
+		entries = append(entries, entry)
+
+		if status == "failed" {
+			retries[name]++
+		}
+	}
+
+	return entries
+}
+
+func parseSeconds(s string) float64 {
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return seconds
+}
+
+// WriteBenchmarkReport writes entries as benchmark.json into outputDir.
+func WriteBenchmarkReport(entries []BenchmarkEntry, outputDir string) (string, error) {
+	path := outputDir + "/" + BenchmarkName
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// TopNSlowest returns the n slowest entries, sorted descending by duration.
+func TopNSlowest(entries []BenchmarkEntry, n int) []BenchmarkEntry {
+	sorted := make([]BenchmarkEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].DurationMS > sorted[j].DurationMS
+	})
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	return sorted[:n]
+}
+
+// LogSlowestTests prints the top-n slowest entries to the standard logger,
+// for the final run summary.
+func LogSlowestTests(entries []BenchmarkEntry, n int) {
+	slowest := TopNSlowest(entries, n)
+	log.Printf("Slowest %d tests:", len(slowest))
+	for _, entry := range slowest {
+		log.Printf("  %6dms  %s  (%s)", entry.DurationMS, entry.Name, entry.Status)
+	}
+}
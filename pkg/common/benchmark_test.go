@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func exampleGinkgoOutput() string {
+	return strings.Join([]string{
+		"• [1.234 seconds] [sig-auth] ServiceAccounts should mount an API token [It]",
+		"• [SLOW TEST:12.345 seconds] [sig-apps] Deployment should run [It]",
+		"• [FAILED] [0.500 seconds] [sig-network] Services should serve endpoints [It]",
+		"• [FAILED] [0.750 seconds] [sig-network] Services should serve endpoints [It]",
+	}, "\n")
+}
+
+func TestParseGinkgoBenchmarks(t *testing.T) {
+	entries := ParseGinkgoBenchmarks(strings.NewReader(exampleGinkgoOutput()))
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d: %+v", len(entries), entries)
+	}
+
+	if entries[0].DurationMS != 1234 || entries[0].Status != "passed" {
+		t.Errorf("unexpected entry for plain result: %+v", entries[0])
+	}
+	if entries[1].DurationMS != 12345 || entries[1].Status != "passed" {
+		t.Errorf("SLOW TEST line not parsed correctly: %+v", entries[1])
+	}
+	if entries[2].Status != "failed" || entries[2].Retries != 0 {
+		t.Errorf("first failure should have zero retries: %+v", entries[2])
+	}
+	if entries[3].Status != "failed" || entries[3].Retries != 1 {
+		t.Errorf("second failure of the same test should count one retry: %+v", entries[3])
+	}
+}
+
+func TestTopNSlowest(t *testing.T) {
+	entries := []BenchmarkEntry{
+		{Name: "a", DurationMS: 100},
+		{Name: "b", DurationMS: 300},
+		{Name: "c", DurationMS: 200},
+	}
+
+	top := TopNSlowest(entries, 2)
+	if len(top) != 2 || top[0].Name != "b" || top[1].Name != "c" {
+		t.Errorf("TopNSlowest(2) = %+v, want [b, c]", top)
+	}
+
+	if all := TopNSlowest(entries, 10); len(all) != len(entries) {
+		t.Errorf("TopNSlowest with n > len(entries) should return all entries, got %d", len(all))
+	}
+}
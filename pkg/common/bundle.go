@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// BundleName is the filename used for the Sonobuoy-style result archive
+// written into OutputDir when ArgConfig.Bundle is set.
+const BundleName = "hydrophone-result.tar.gz"
+
+// CreateResultBundle tars and gzips every file under outputDir, at its path
+// relative to outputDir, into outputDir/BundleName. It archives only what is
+// already there (such as junit_01.xml, benchmark.json and per-run
+// subdirectories); it collects no cluster metadata, server version or
+// node/namespace snapshots of its own, and does not rearrange the files into
+// a Sonobuoy result archive's directory layout.
+func CreateResultBundle(outputDir string) (string, error) {
+	bundlePath := filepath.Join(outputDir, BundleName)
+
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		return "", fmt.Errorf("creating bundle file: %w", err)
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	err = filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == bundlePath {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = relPath
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("writing bundle: %w", err)
+	}
+
+	// tw and gw must be closed, in order, before the gzip/tar trailers are
+	// flushed to f - checking their errors here (rather than via defer) is
+	// what catches a write failure, e.g. the disk filling up mid-bundle.
+	if err := tw.Close(); err != nil {
+		return "", fmt.Errorf("closing bundle tar writer: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return "", fmt.Errorf("closing bundle gzip writer: %w", err)
+	}
+
+	return bundlePath, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ImageConfig describes a single image reference and its pull policy, as
+// used under the top-level `images:` section of a PlanConfig.
+type ImageConfig struct {
+	Name       string `json:"name,omitempty"`
+	PullPolicy string `json:"pullPolicy,omitempty"`
+}
+
+// TestRun describes a single entry in the `runs:` list of a PlanConfig. Each
+// run gets its own focus/skip/parallel/output-dir, and runs execute in the
+// order they're declared.
+type TestRun struct {
+	Name      string `json:"name,omitempty"`
+	Focus     string `json:"focus,omitempty"`
+	Skip      string `json:"skip,omitempty"`
+	Parallel  int    `json:"parallel,omitempty"`
+	OutputDir string `json:"outputDir,omitempty"`
+}
+
+// PlanConfig is the YAML document accepted by --config, modeled on
+// cluster-api's e2e config: passthrough variables for the conformance pod,
+// the images to run it with, and an ordered list of runs. This lets CI
+// pipelines express things like "quick smoke, then full conformance, then
+// serial-only" in one invocation.
+type PlanConfig struct {
+	Variables map[string]string `json:"variables,omitempty"`
+	Images    struct {
+		Conformance ImageConfig `json:"conformance,omitempty"`
+		Busybox     ImageConfig `json:"busybox,omitempty"`
+	} `json:"images,omitempty"`
+	Runs []TestRun `json:"runs,omitempty"`
+}
+
+// LoadPlanConfig reads and parses the YAML document at path.
+func LoadPlanConfig(path string) (*PlanConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file %s: %w", path, err)
+	}
+
+	var plan PlanConfig
+	if err := yaml.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	if len(plan.Runs) == 0 {
+		return nil, fmt.Errorf("config file %s declares no runs", path)
+	}
+
+	return &plan, nil
+}
+
+// mergePlanConfig folds a PlanConfig into cfg. For the top-level images, CLI
+// flags win over file values: an image is only taken from the file when the
+// corresponding flag wasn't explicitly set on the command line (tracked in
+// explicitFlags). Within each run, the run's own focus/skip/parallel/outputDir
+// take precedence: CLI values only fill in what a run leaves unset, and
+// focus/skip only when --focus/--skip were set explicitly. Variables are
+// always taken from the file.
+func mergePlanConfig(cfg *ArgConfig, plan *PlanConfig, explicitFlags map[string]bool) {
+	if !explicitFlags["conformance-image"] && plan.Images.Conformance.Name != "" {
+		cfg.ConformanceImage = plan.Images.Conformance.Name
+	}
+	if !explicitFlags["busybox-image"] && plan.Images.Busybox.Name != "" {
+		cfg.BusyboxImage = plan.Images.Busybox.Name
+	}
+
+	cfg.Variables = plan.Variables
+
+	cfg.Runs = make([]TestRun, len(plan.Runs))
+	for i, run := range plan.Runs {
+		if explicitFlags["focus"] && run.Focus == "" {
+			run.Focus = cfg.Focus
+		}
+		if explicitFlags["skip"] && run.Skip == "" {
+			run.Skip = cfg.Skip
+		}
+		if run.Parallel == 0 {
+			run.Parallel = cfg.Parallel
+		}
+		if run.OutputDir == "" {
+			// Runs that don't set their own outputDir each get a distinct
+			// subdirectory of cfg.OutputDir, named after the run, so their
+			// junit_01.xml files don't overwrite one another.
+			name := run.Name
+			if name == "" {
+				name = fmt.Sprintf("run-%d", i+1)
+			}
+			run.OutputDir = filepath.Join(cfg.OutputDir, name)
+		}
+		cfg.Runs[i] = run
+	}
+}
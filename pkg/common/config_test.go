@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import "testing"
+
+func TestMergePlanConfigCLIWins(t *testing.T) {
+	cfg := &ArgConfig{
+		Focus:            "[sig-auth]",
+		ConformanceImage: "my-registry/conformance:v1.30.0",
+		Parallel:         1,
+		OutputDir:        "/out",
+	}
+	plan := &PlanConfig{
+		Variables: map[string]string{"KUBECONFIG": "/kubeconfig"},
+		Runs: []TestRun{
+			{Name: "quick", Skip: "[Serial]"},
+			{Name: "full", Focus: "[Conformance]", Parallel: 4, OutputDir: "/out/full"},
+		},
+	}
+	plan.Images.Conformance.Name = "registry.k8s.io/conformance:v1.28.0"
+
+	explicitFlags := map[string]bool{"conformance-image": true, "focus": true}
+
+	mergePlanConfig(cfg, plan, explicitFlags)
+
+	if cfg.ConformanceImage != "my-registry/conformance:v1.30.0" {
+		t.Errorf("explicit --conformance-image should win over the file, got %q", cfg.ConformanceImage)
+	}
+	if cfg.Variables["KUBECONFIG"] != "/kubeconfig" {
+		t.Errorf("Variables should be populated from the plan, got %v", cfg.Variables)
+	}
+	if len(cfg.Runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(cfg.Runs))
+	}
+	if cfg.Runs[0].Focus != cfg.Focus {
+		t.Errorf("run with no focus of its own should pick up an explicit CLI --focus, got %q", cfg.Runs[0].Focus)
+	}
+	if cfg.Runs[1].Focus != "[Conformance]" {
+		t.Errorf("run's own focus should not be overridden, got %q", cfg.Runs[1].Focus)
+	}
+	if cfg.Runs[0].OutputDir != "/out/quick" {
+		t.Errorf("run with no output dir of its own should get a distinct subdirectory of the CLI output dir, got %q", cfg.Runs[0].OutputDir)
+	}
+	if cfg.Runs[1].OutputDir != "/out/full" {
+		t.Errorf("run's own output dir should not be overridden, got %q", cfg.Runs[1].OutputDir)
+	}
+}
+
+func TestMergePlanConfigFocusNotPropagatedWithoutExplicitFlag(t *testing.T) {
+	cfg := &ArgConfig{Focus: "[sig-auth]", OutputDir: "/out"}
+	plan := &PlanConfig{
+		Runs: []TestRun{{Name: "quick"}},
+	}
+
+	mergePlanConfig(cfg, plan, map[string]bool{})
+
+	if cfg.Runs[0].Focus != "" {
+		t.Errorf("run focus should stay empty when --focus wasn't explicitly set, got %q", cfg.Runs[0].Focus)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DryRunGinkgoArgs returns the ginkgo invocation hydrophone passes to the
+// conformance image for a --dry-run: it lists the tests E2E_FOCUS/E2E_SKIP
+// would select without actually running them.
+func DryRunGinkgoArgs(cfg *ArgConfig) []string {
+	args := []string{"--ginkgo.dryRun", "--ginkgo.v"}
+	if cfg.Focus != "" {
+		args = append(args, fmt.Sprintf("--ginkgo.focus=%s", cfg.Focus))
+	}
+	if cfg.Skip != "" {
+		args = append(args, fmt.Sprintf("--ginkgo.skip=%s", cfg.Skip))
+	}
+	return args
+}
+
+// ginkgoSpecMarker precedes each spec ginkgo's dry run lists, e.g.
+// "• [0.000 seconds]"; the spec name follows on the next non-empty line.
+var ginkgoSpecMarker = "• ["
+
+// ParseGinkgoDryRunNames extracts the spec names ginkgo printed while
+// running with --ginkgo.dryRun --ginkgo.v.
+func ParseGinkgoDryRunNames(r io.Reader) []string {
+	var names []string
+	scanner := bufio.NewScanner(r)
+	expectName := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if expectName {
+			if line != "" {
+				names = append(names, line)
+				expectName = false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ginkgoSpecMarker) {
+			expectName = true
+		}
+	}
+	return names
+}
+
+// WriteDryRunList writes names (one per line) to stdout, and also to path
+// when path is non-empty.
+func WriteDryRunList(names []string, path string) error {
+	out := strings.Join(names, "\n") + "\n"
+
+	if _, err := fmt.Print(out); err != nil {
+		return err
+	}
+
+	if path == "" {
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+		return fmt.Errorf("writing dry-run test list to %s: %w", path, err)
+	}
+
+	return nil
+}
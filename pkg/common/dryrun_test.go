@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDryRunGinkgoArgs(t *testing.T) {
+	cfg := &ArgConfig{Focus: "sig-auth", Skip: "sig-network"}
+	args := DryRunGinkgoArgs(cfg)
+
+	want := []string{"--ginkgo.dryRun", "--ginkgo.v", "--ginkgo.focus=sig-auth", "--ginkgo.skip=sig-network"}
+	if len(args) != len(want) {
+		t.Fatalf("DryRunGinkgoArgs() = %v, want %v", args, want)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Errorf("DryRunGinkgoArgs()[%d] = %q, want %q", i, args[i], want[i])
+		}
+	}
+}
+
+func TestDryRunGinkgoArgsNoFocusOrSkip(t *testing.T) {
+	args := DryRunGinkgoArgs(&ArgConfig{})
+	want := []string{"--ginkgo.dryRun", "--ginkgo.v"}
+	if len(args) != len(want) || args[0] != want[0] || args[1] != want[1] {
+		t.Errorf("DryRunGinkgoArgs() = %v, want %v", args, want)
+	}
+}
+
+func TestParseGinkgoDryRunNames(t *testing.T) {
+	logs := strings.Join([]string{
+		"• [0.000 seconds]",
+		"[sig-auth] ServiceAccounts should mount an API token",
+		"test/e2e/auth/service_accounts.go:112",
+		"------------------------------",
+		"• [0.000 seconds]",
+		"[sig-apps] Deployment should run",
+		"test/e2e/apps/deployment.go:57",
+	}, "\n")
+
+	names := ParseGinkgoDryRunNames(strings.NewReader(logs))
+	want := []string{
+		"[sig-auth] ServiceAccounts should mount an API token",
+		"[sig-apps] Deployment should run",
+	}
+	if len(names) != len(want) {
+		t.Fatalf("ParseGinkgoDryRunNames() = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("ParseGinkgoDryRunNames()[%d] = %q, want %q", i, names[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// TestImageProvider resolves the images hydrophone runs the conformance
+// suite with. Built-in implementations cover the common registry.k8s.io
+// case, a private mirror, and an air-gapped cluster with no outbound
+// registry access at all.
+type TestImageProvider interface {
+	// Name identifies the provider for logging.
+	Name() string
+	// Conformance returns the conformance image for the given discovered
+	// Kubernetes server version (e.g. "v1.29.2").
+	Conformance(k8sVersion string) string
+	// Busybox returns the busybox image used for auxiliary pods.
+	Busybox() string
+	// ExtraImages returns any additional images the provider pre-stages,
+	// keyed by a short identifier (e.g. "pause").
+	ExtraImages() map[string]string
+	// PullSecret returns the name of the ImagePullSecret pods should use to
+	// pull the provider's images, or "" if none is needed.
+	PullSecret() string
+}
+
+// upstreamImageProvider pulls images directly from registry.k8s.io, the
+// default for clusters with normal outbound access.
+type upstreamImageProvider struct{}
+
+func (upstreamImageProvider) Name() string { return "upstream (registry.k8s.io)" }
+
+func (upstreamImageProvider) Conformance(k8sVersion string) string {
+	return selectConformanceImage(k8sVersion)
+}
+
+func (upstreamImageProvider) Busybox() string {
+	return "registry.k8s.io/e2e-test-images/busybox:1.36.1-1"
+}
+
+func (upstreamImageProvider) ExtraImages() map[string]string { return nil }
+
+func (upstreamImageProvider) PullSecret() string { return "" }
+
+// mirrorImageProvider rewrites images onto a user-supplied private
+// registry mirror, optionally authenticating with a Docker config JSON
+// file (the same format `docker login` writes).
+type mirrorImageProvider struct {
+	mirror     string
+	pullSecret string
+}
+
+func (p mirrorImageProvider) Name() string {
+	return fmt.Sprintf("private mirror (%s)", p.mirror)
+}
+
+func (p mirrorImageProvider) Conformance(k8sVersion string) string {
+	return fmt.Sprintf("%s/conformance:%s", p.mirror, normalizeVersion(k8sVersion))
+}
+
+func (p mirrorImageProvider) Busybox() string {
+	return fmt.Sprintf("%s/e2e-test-images/busybox:1.36.1-1", p.mirror)
+}
+
+func (p mirrorImageProvider) ExtraImages() map[string]string { return nil }
+
+func (p mirrorImageProvider) PullSecret() string { return p.pullSecret }
+
+// airGappedImageProvider expects no registry access at all: images are
+// pre-loaded onto every node from a local tarball via a DaemonSet, and the
+// provider simply references them by their in-tarball tag.
+type airGappedImageProvider struct {
+	tarballPath string
+}
+
+func (p airGappedImageProvider) Name() string {
+	return fmt.Sprintf("air-gapped (tarball %s)", p.tarballPath)
+}
+
+func (airGappedImageProvider) Conformance(k8sVersion string) string {
+	return fmt.Sprintf("conformance:%s", normalizeVersion(k8sVersion))
+}
+
+func (airGappedImageProvider) Busybox() string {
+	return "e2e-test-images/busybox:1.36.1-1"
+}
+
+func (airGappedImageProvider) ExtraImages() map[string]string { return nil }
+
+func (airGappedImageProvider) PullSecret() string { return "" }
+
+func normalizeVersion(k8sVersion string) string {
+	v := strings.TrimPrefix(k8sVersion, "v")
+	if idx := strings.IndexAny(v, "-+"); idx != -1 {
+		v = v[:idx]
+	}
+	return "v" + v
+}
+
+// NewTestImageProvider selects a TestImageProvider based on cfg: an
+// air-gapped tarball takes precedence over a registry mirror, which in turn
+// takes precedence over the upstream registry.k8s.io default. When cfg
+// selects a registry mirror with a RegistryAuthFile, the Docker config JSON
+// is stored as a pull secret in namespace so the returned provider's
+// PullSecret() can be attached to pods.
+func NewTestImageProvider(ctx context.Context, clientset kubernetes.Interface, namespace string, cfg *ArgConfig) (TestImageProvider, error) {
+	switch {
+	case cfg.AirGappedTarball != "":
+		return airGappedImageProvider{tarballPath: cfg.AirGappedTarball}, nil
+	case cfg.RegistryMirror != "":
+		var pullSecret string
+		if cfg.RegistryAuthFile != "" {
+			secretName, err := ensureRegistryPullSecret(ctx, clientset, namespace, cfg.RegistryAuthFile)
+			if err != nil {
+				return nil, fmt.Errorf("configuring registry mirror auth: %w", err)
+			}
+			pullSecret = secretName
+		}
+		return mirrorImageProvider{mirror: cfg.RegistryMirror, pullSecret: pullSecret}, nil
+	default:
+		return upstreamImageProvider{}, nil
+	}
+}
+
+// preloadDaemonSetName is the DaemonSet hydrophone creates in air-gapped
+// mode to load the conformance tarball onto every node before the
+// conformance pod is scheduled.
+const preloadDaemonSetName = "hydrophone-image-preload"
+
+// EnsureAirGappedImages makes sure the tarball at tarballPath has been
+// loaded onto every node in the cluster: it rolls out a DaemonSet, streams
+// the tarball from the operator's machine into each of its pods (there's no
+// other way for a node to see a file that only exists locally), and waits
+// for every node to finish importing it into its container runtime.
+func EnsureAirGappedImages(ctx context.Context, restConfig *rest.Config, clientset kubernetes.Interface, namespace, tarballPath string) error {
+	ds := buildPreloadDaemonSet(namespace)
+
+	existing, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, preloadDaemonSetName, metav1.GetOptions{})
+	if err == nil {
+		existing.Spec = ds.Spec
+		if _, err := clientset.AppsV1().DaemonSets(namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("updating %s daemonset: %w", preloadDaemonSetName, err)
+		}
+	} else if apierrors.IsNotFound(err) {
+		if _, err := clientset.AppsV1().DaemonSets(namespace).Create(ctx, ds, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("creating %s daemonset: %w", preloadDaemonSetName, err)
+		}
+	} else {
+		return fmt.Errorf("getting %s daemonset: %w", preloadDaemonSetName, err)
+	}
+
+	pods, err := waitForPreloadPodsScheduled(ctx, clientset, namespace, 5*time.Minute)
+	if err != nil {
+		return fmt.Errorf("waiting for %s pods to be scheduled: %w", preloadDaemonSetName, err)
+	}
+
+	for _, pod := range pods {
+		if err := copyTarballToPod(ctx, restConfig, clientset, namespace, pod.Name, tarballPath); err != nil {
+			return fmt.Errorf("copying tarball to pod %s: %w", pod.Name, err)
+		}
+	}
+
+	return waitForDaemonSetReady(ctx, clientset, namespace, preloadDaemonSetName, 5*time.Minute)
+}
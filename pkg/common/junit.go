@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+// junitTestSuite mirrors the subset of the JUnit schema junit_01.xml files
+// from the conformance image actually populate.
+type junitTestSuite struct {
+	XMLName  xml.Name `xml:"testsuite"`
+	Name     string   `xml:"name,attr"`
+	Tests    int      `xml:"tests,attr"`
+	Failures int      `xml:"failures,attr"`
+	Skipped  int      `xml:"skipped,attr"`
+	Time     float64  `xml:"time,attr"`
+	Inner    string   `xml:",innerxml"`
+}
+
+// junitTestSuites is the aggregate document written out for a multi-run
+// test plan: one <testsuite> per run, wrapped in a single <testsuites> root
+// so existing junit tooling (testgrid uploaders, `sonobuoy results`, CI
+// dashboards) can consume it unchanged.
+type junitTestSuites struct {
+	XMLName xml.Name          `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+// AggregateJUnitReports reads the junit_01.xml produced by each run in
+// runOutputDirs (in order) and writes a single combined report to outPath,
+// one <testsuite> per run.
+func AggregateJUnitReports(runOutputDirs []string, outPath string) error {
+	var combined junitTestSuites
+
+	for _, dir := range runOutputDirs {
+		path := dir + "/junit_01.xml"
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading junit report %s: %w", path, err)
+		}
+
+		var suite junitTestSuite
+		if err := xml.Unmarshal(data, &suite); err != nil {
+			return fmt.Errorf("parsing junit report %s: %w", path, err)
+		}
+
+		combined.Suites = append(combined.Suites, suite)
+	}
+
+	out, err := xml.MarshalIndent(combined, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling combined junit report: %w", err)
+	}
+
+	if err := os.WriteFile(outPath, out, 0644); err != nil {
+		return fmt.Errorf("writing combined junit report %s: %w", outPath, err)
+	}
+
+	return nil
+}
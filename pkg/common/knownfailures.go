@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// knownFailure pairs a range of server versions with the E2E_SKIP entries
+// known to be broken on those versions.
+type knownFailure struct {
+	Range semver.Range
+	Skips []string
+}
+
+// knownFailures is the in-tree table of version-specific skips applied when
+// --skip-known-failures is set.
+var knownFailures = []knownFailure{
+	{
+		Range: semver.MustParseRange(">=1.29.0"),
+		Skips: []string{
+			"Services should serve endpoints on same port and different protocols",
+		},
+	},
+}
+
+// resolveKnownFailureSkips returns the E2E_SKIP entries that apply to
+// serverVersion, based on the knownFailures table.
+func resolveKnownFailureSkips(serverVersion semver.Version) []string {
+	var skips []string
+	for _, kf := range knownFailures {
+		if kf.Range(serverVersion) {
+			skips = append(skips, kf.Skips...)
+		}
+	}
+	return skips
+}
+
+// parseServerVersion normalizes a Kubernetes discovery version string (e.g.
+// "v1.29.2", "1.29.2-eks-1234") into a semver.Version suitable for matching
+// against the knownFailures table.
+func parseServerVersion(gitVersion string) (semver.Version, error) {
+	v := strings.TrimPrefix(gitVersion, "v")
+	// Kubernetes versions carry build metadata such as "-eks-1234" that
+	// semver treats as a pre-release/build tag; strip anything past the
+	// major.minor.patch core so comparisons stay well-defined.
+	if idx := strings.IndexAny(v, "-+"); idx != -1 {
+		v = v[:idx]
+	}
+	return semver.Parse(v)
+}
+
+// selectConformanceImage picks a version-appropriate conformance image for
+// the discovered server version, so users don't need to hardcode one.
+func selectConformanceImage(gitVersion string) string {
+	v := strings.TrimPrefix(gitVersion, "v")
+	if idx := strings.IndexAny(v, "-+"); idx != -1 {
+		v = v[:idx]
+	}
+	return fmt.Sprintf("registry.k8s.io/conformance:v%s", v)
+}
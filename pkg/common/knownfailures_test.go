@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	cases := []struct {
+		gitVersion string
+		want       string
+	}{
+		{"v1.29.2", "1.29.2"},
+		{"1.29.2-eks-1234", "1.29.2"},
+		{"v1.28.0+vmware.1", "1.28.0"},
+	}
+
+	for _, c := range cases {
+		got, err := parseServerVersion(c.gitVersion)
+		if err != nil {
+			t.Fatalf("parseServerVersion(%q) returned error: %v", c.gitVersion, err)
+		}
+		if got.String() != c.want {
+			t.Errorf("parseServerVersion(%q) = %q, want %q", c.gitVersion, got.String(), c.want)
+		}
+	}
+}
+
+func TestResolveKnownFailureSkips(t *testing.T) {
+	v129 := semver.MustParse("1.29.0")
+	skips := resolveKnownFailureSkips(v129)
+	if len(skips) != 1 || skips[0] != "Services should serve endpoints on same port and different protocols" {
+		t.Errorf("resolveKnownFailureSkips(1.29.0) = %v, want the known v1.29+ skip", skips)
+	}
+
+	v128 := semver.MustParse("1.28.5")
+	if skips := resolveKnownFailureSkips(v128); len(skips) != 0 {
+		t.Errorf("resolveKnownFailureSkips(1.28.5) = %v, want no skips", skips)
+	}
+}
+
+func TestSelectConformanceImage(t *testing.T) {
+	got := selectConformanceImage("v1.29.2-eks-1234")
+	want := "registry.k8s.io/conformance:v1.29.2"
+	if got != want {
+		t.Errorf("selectConformanceImage(%q) = %q, want %q", "v1.29.2-eks-1234", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// pullProbePodName is the short-lived pod hydrophone creates to verify pull
+// access for an image before the real conformance pod is scheduled.
+const pullProbePodName = "hydrophone-pull-probe"
+
+// VerifyPullAccess creates a short-lived pod for each image and waits for
+// its container to either start or report an image pull failure. When
+// pullSecret is non-empty, it's attached to the probe pods as an
+// ImagePullSecret, matching what the conformance pod itself uses.
+func VerifyPullAccess(ctx context.Context, clientset kubernetes.Interface, namespace string, images []string, pullSecret string) error {
+	for i, image := range images {
+		name := fmt.Sprintf("%s-%d", pullProbePodName, i)
+		if err := verifyImagePullAccess(ctx, clientset, namespace, name, image, pullSecret); err != nil {
+			return fmt.Errorf("verifying pull access for %s: %w", image, err)
+		}
+	}
+	return nil
+}
+
+func verifyImagePullAccess(ctx context.Context, clientset kubernetes.Interface, namespace, name, image, pullSecret string) error {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:    "probe",
+					Image:   image,
+					Command: []string{"/bin/true"},
+				},
+			},
+		},
+	}
+	if pullSecret != "" {
+		pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: pullSecret}}
+	}
+
+	defer func() {
+		_ = clientset.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	}()
+
+	if _, err := clientset.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, time.Minute, true, func(ctx context.Context) (bool, error) {
+		p, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range p.Status.ContainerStatuses {
+			if waiting := status.State.Waiting; waiting != nil {
+				if waiting.Reason == "ImagePullBackOff" || waiting.Reason == "ErrImagePull" {
+					return false, fmt.Errorf("%s: %s", waiting.Reason, waiting.Message)
+				}
+			}
+			if status.State.Running != nil || status.State.Terminated != nil {
+				return true, nil
+			}
+		}
+		return p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed, nil
+	})
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// registryPullSecretName is the secret hydrophone creates from
+// --registry-auth-file to authenticate against --registry-mirror.
+const registryPullSecretName = "hydrophone-registry-auth"
+
+// ensureRegistryPullSecret stores the Docker config JSON at authFile as a
+// kubernetes.io/dockerconfigjson secret in namespace, creating or updating
+// it as needed, and returns the secret's name for use as an
+// ImagePullSecret.
+func ensureRegistryPullSecret(ctx context.Context, clientset kubernetes.Interface, namespace, authFile string) (string, error) {
+	data, err := os.ReadFile(authFile)
+	if err != nil {
+		return "", fmt.Errorf("reading registry auth file %s: %w", authFile, err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: registryPullSecretName, Namespace: namespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: data},
+	}
+
+	existing, err := clientset.CoreV1().Secrets(namespace).Get(ctx, registryPullSecretName, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		existing.Data = secret.Data
+		if _, err := clientset.CoreV1().Secrets(namespace).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("updating registry pull secret: %w", err)
+		}
+	case apierrors.IsNotFound(err):
+		if _, err := clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return "", fmt.Errorf("creating registry pull secret: %w", err)
+		}
+	default:
+		return "", fmt.Errorf("getting registry pull secret: %w", err)
+	}
+
+	return registryPullSecretName, nil
+}
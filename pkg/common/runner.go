@@ -0,0 +1,277 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// conformancePodName is the pod hydrophone runs the conformance suite in.
+const conformancePodName = "hydrophone-conformance"
+
+// conformanceResultsDir is where the conformance image writes e2e.log and
+// junit_01.xml inside the pod.
+const conformanceResultsDir = "/tmp/results"
+
+// junitFileName is the junit report the conformance image writes to
+// conformanceResultsDir, and the name hydrophone gives the combined report.
+const junitFileName = "junit_01.xml"
+
+// Run executes the configured test run(s) against the cluster: each entry
+// in cfg.Runs (or, when --config wasn't used, a single implicit run built
+// from the rest of cfg) runs sequentially, and their junit_01.xml reports
+// are aggregated into a single combined report when there's more than one.
+// It finishes with any requested post-run step, such as --bundle. When
+// cfg.DryRun is set, it instead lists the selected tests via runDryRun and
+// returns without performing a real run.
+func Run(ctx context.Context, restConfig *rest.Config, clientset kubernetes.Interface, namespace string, cfg *ArgConfig) error {
+	if cfg.DryRun {
+		return runDryRun(ctx, clientset, namespace, cfg)
+	}
+
+	runs := cfg.Runs
+	if len(runs) == 0 {
+		runs = []TestRun{{Focus: cfg.Focus, Skip: cfg.Skip, Parallel: cfg.Parallel, OutputDir: cfg.OutputDir}}
+	}
+
+	var outputDirs []string
+	var benchmarkEntries []BenchmarkEntry
+
+	for _, run := range runs {
+		if run.Name != "" {
+			log.Printf("Starting run '%s' : focus=%q skip=%q", run.Name, run.Focus, run.Skip)
+		}
+
+		if err := os.MkdirAll(run.OutputDir, 0755); err != nil {
+			return fmt.Errorf("creating output directory %s: %w", run.OutputDir, err)
+		}
+
+		env := []corev1.EnvVar{
+			{Name: "E2E_FOCUS", Value: run.Focus},
+			{Name: "E2E_SKIP", Value: run.Skip},
+			{Name: "E2E_PARALLEL", Value: strconv.Itoa(run.Parallel)},
+			{Name: "E2E_VERBOSITY", Value: strconv.Itoa(cfg.Verbosity)},
+		}
+		for k, v := range cfg.Variables {
+			env = append(env, corev1.EnvVar{Name: k, Value: v})
+		}
+
+		logs, err := runConformancePod(ctx, restConfig, clientset, namespace, cfg.ConformanceImage, cfg.imagePullSecret, env, nil, run.OutputDir)
+		if err != nil {
+			return fmt.Errorf("run %q: %w", run.Name, err)
+		}
+
+		if cfg.Benchmark {
+			benchmarkEntries = append(benchmarkEntries, ParseGinkgoBenchmarks(strings.NewReader(logs))...)
+		}
+
+		outputDirs = append(outputDirs, run.OutputDir)
+	}
+
+	if cfg.Benchmark {
+		benchmarkPath, err := WriteBenchmarkReport(benchmarkEntries, cfg.OutputDir)
+		if err != nil {
+			return fmt.Errorf("writing benchmark report: %w", err)
+		}
+		log.Printf("Wrote benchmark report : '%s'", benchmarkPath)
+		LogSlowestTests(benchmarkEntries, 10)
+	}
+
+	if len(outputDirs) > 1 {
+		combined := cfg.OutputDir + "/" + junitFileName
+		if err := AggregateJUnitReports(outputDirs, combined); err != nil {
+			return fmt.Errorf("aggregating junit reports: %w", err)
+		}
+		log.Printf("Wrote combined junit report : '%s'", combined)
+	}
+
+	if cfg.Bundle {
+		bundlePath, err := CreateResultBundle(cfg.OutputDir)
+		if err != nil {
+			return fmt.Errorf("creating result bundle: %w", err)
+		}
+		log.Printf("Wrote result bundle : '%s'", bundlePath)
+	}
+
+	return nil
+}
+
+// runConformancePod creates the conformance pod with the given env and
+// extraArgs, waits for it to finish, copies junit_01.xml out into
+// outputDir, and returns the pod's captured log output.
+func runConformancePod(ctx context.Context, restConfig *rest.Config, clientset kubernetes.Interface, namespace, image, pullSecret string, env []corev1.EnvVar, extraArgs []string, outputDir string) (string, error) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: conformancePodName, Namespace: namespace},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:  "conformance",
+					Image: image,
+					Args:  extraArgs,
+					Env:   env,
+				},
+			},
+		},
+	}
+	if pullSecret != "" {
+		pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: pullSecret}}
+	}
+
+	// Clean up a pod left over from a previous, possibly failed, run before
+	// creating a fresh one.
+	_ = clientset.CoreV1().Pods(namespace).Delete(ctx, conformancePodName, metav1.DeleteOptions{})
+
+	if _, err := clientset.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("creating conformance pod: %w", err)
+	}
+
+	if err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 2*time.Hour, true, func(ctx context.Context) (bool, error) {
+		p, err := clientset.CoreV1().Pods(namespace).Get(ctx, conformancePodName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed, nil
+	}); err != nil {
+		return "", fmt.Errorf("waiting for conformance pod to finish: %w", err)
+	}
+
+	logs, err := getPodLogs(ctx, clientset, namespace, conformancePodName)
+	if err != nil {
+		return "", err
+	}
+
+	junitPath := outputDir + "/" + junitFileName
+	if err := copyFileFromPod(ctx, restConfig, clientset, namespace, conformancePodName, "conformance",
+		conformanceResultsDir+"/"+junitFileName, junitPath); err != nil {
+		return "", fmt.Errorf("copying %s out of conformance pod: %w", junitFileName, err)
+	}
+
+	return logs, nil
+}
+
+// runDryRun invokes the conformance image with DryRunGinkgoArgs instead of a
+// real run, parses the spec names it lists out of the pod's logs, and writes
+// them via WriteDryRunList. Unlike a real run, there's no junit report to
+// copy out, so the pod is simply deleted once its logs have been collected.
+func runDryRun(ctx context.Context, clientset kubernetes.Interface, namespace string, cfg *ArgConfig) error {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: conformancePodName, Namespace: namespace},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{Name: "conformance", Image: cfg.ConformanceImage, Args: DryRunGinkgoArgs(cfg)},
+			},
+		},
+	}
+	if cfg.imagePullSecret != "" {
+		pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: cfg.imagePullSecret}}
+	}
+
+	_ = clientset.CoreV1().Pods(namespace).Delete(ctx, conformancePodName, metav1.DeleteOptions{})
+	defer func() {
+		_ = clientset.CoreV1().Pods(namespace).Delete(ctx, conformancePodName, metav1.DeleteOptions{})
+	}()
+
+	if _, err := clientset.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("creating dry-run pod: %w", err)
+	}
+
+	if err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		p, err := clientset.CoreV1().Pods(namespace).Get(ctx, conformancePodName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed, nil
+	}); err != nil {
+		return fmt.Errorf("waiting for dry-run pod to finish: %w", err)
+	}
+
+	logs, err := getPodLogs(ctx, clientset, namespace, conformancePodName)
+	if err != nil {
+		return err
+	}
+
+	names := ParseGinkgoDryRunNames(strings.NewReader(logs))
+	log.Printf("Dry run selected '%d' tests", len(names))
+	return WriteDryRunList(names, cfg.DryRunOutput)
+}
+
+func getPodLogs(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (string, error) {
+	stream, err := clientset.CoreV1().Pods(namespace).GetLogs(name, &corev1.PodLogOptions{}).Stream(ctx)
+	if err != nil {
+		return "", fmt.Errorf("streaming pod logs: %w", err)
+	}
+	defer stream.Close()
+
+	var logs bytes.Buffer
+	if _, err := io.Copy(&logs, stream); err != nil {
+		return "", fmt.Errorf("reading pod logs: %w", err)
+	}
+
+	return logs.String(), nil
+}
+
+// copyFileFromPod execs `cat srcPath` in container and writes the output to
+// dstPath, mirroring what `kubectl cp` does under the hood.
+func copyFileFromPod(ctx context.Context, restConfig *rest.Config, clientset kubernetes.Interface, namespace, pod, container, srcPath, dstPath string) error {
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   []string{"cat", srcPath},
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("building exec request: %w", err)
+	}
+
+	out, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	var stderr bytes.Buffer
+	if err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: out, Stderr: &stderr}); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+
+	return nil
+}